@@ -13,12 +13,15 @@ import (
 	"github.com/smallstep/cli/command"
 	"github.com/smallstep/cli/command/version"
 	"github.com/smallstep/cli/config"
+	"github.com/smallstep/cli/internal/plugin"
 	"github.com/smallstep/cli/usage"
 
 	// Enabled commands
 	_ "github.com/smallstep/cli/command/certificate"
 	_ "github.com/smallstep/cli/command/crypto"
 	_ "github.com/smallstep/cli/command/oauth"
+	_ "github.com/smallstep/cli/command/plugin"
+	_ "github.com/smallstep/cli/command/upgrade"
 
 	// Profiling and debugging
 	_ "net/http/pprof"
@@ -59,6 +62,31 @@ func main() {
 	app.EnableBashCompletion = true
 	app.Copyright = "(c) 2018 Smallstep Labs, Inc."
 
+	// Transparently dispatch unknown subcommands to `step-<name>-plugin`
+	// executables before urfave/cli gets a chance to complain about them,
+	// forwarding stdin/stdout/stderr and the plugin's exit code.
+	if len(os.Args) > 1 {
+		name := os.Args[1]
+		if !isBuiltinCommand(app.Commands, name) && name != "help" && name != "h" {
+			if path, err := plugin.LookPath(name); err == nil {
+				code, err := plugin.Run(path, os.Args[2:])
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					os.Exit(1)
+				}
+				os.Exit(code)
+			}
+		}
+	}
+
+	// Merge discovered plugins into app.Commands so they show up in `step
+	// help`, but only when help is actually being requested: discovering and
+	// querying every plugin on $PATH/$STEPPATH/plugins is too expensive to
+	// pay on the hot path of every `step` invocation.
+	if isHelpRequest(os.Args) {
+		app.Commands = append(app.Commands, pluginCommands(app.Commands)...)
+	}
+
 	// All non-successful output should be written to stderr
 	app.Writer = os.Stdout
 	app.ErrWriter = os.Stderr
@@ -90,6 +118,62 @@ func flagValue(f cli.Flag) reflect.Value {
 	return fv
 }
 
+// isHelpRequest returns true if args asks step to print help, either with no
+// command at all or with an explicit "help"/"h"/"--help"/"-h".
+func isHelpRequest(args []string) bool {
+	if len(args) < 2 {
+		return true
+	}
+	switch args[1] {
+	case "help", "h", "--help", "-h":
+		return true
+	default:
+		return false
+	}
+}
+
+// isBuiltinCommand returns true if name matches one of the app's built-in
+// top-level commands.
+func isBuiltinCommand(commands []cli.Command, name string) bool {
+	for _, cmd := range commands {
+		if cmd.HasName(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginCommands returns a stub cli.Command for every plugin found on $PATH
+// or in $STEPPATH/plugins that doesn't shadow a built-in command, so that
+// `step help` lists installed plugins alongside native subcommands.
+func pluginCommands(builtin []cli.Command) []cli.Command {
+	var commands []cli.Command
+	for _, name := range plugin.List() {
+		if isBuiltinCommand(builtin, name) {
+			continue
+		}
+		path, err := plugin.LookPath(name)
+		if err != nil {
+			continue
+		}
+		info, err := plugin.GetInfo(name, path)
+		if err != nil {
+			if os.Getenv("STEPDEBUG") == "1" {
+				fmt.Fprintf(os.Stderr, "failed to load plugin %s: %v\n", name, err)
+			}
+			info = &plugin.Info{Name: name, Usage: "step plugin"}
+		}
+		commands = append(commands, cli.Command{
+			Name:  info.Name,
+			Usage: info.Usage,
+			Action: func(c *cli.Context) error {
+				return cli.ShowCommandHelp(c, info.Name)
+			},
+		})
+	}
+	return commands
+}
+
 var placeholderString = regexp.MustCompile(`<.*?>`)
 
 func stringifyFlag(f cli.Flag) string {
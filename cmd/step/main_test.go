@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestIsHelpRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{"no args", []string{"step"}, true},
+		{"help", []string{"step", "help"}, true},
+		{"h", []string{"step", "h"}, true},
+		{"--help", []string{"step", "--help"}, true},
+		{"-h", []string{"step", "-h"}, true},
+		{"version", []string{"step", "version"}, false},
+		{"subcommand", []string{"step", "crypto", "jwk", "create"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isHelpRequest(tt.args); got != tt.want {
+				t.Errorf("isHelpRequest(%v) = %v, want %v", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+// Package crypto implements the `step crypto` command group, a collection
+// of utilities for working with keys, certificates and other cryptographic
+// primitives.
+package crypto
+
+import (
+	"github.com/urfave/cli"
+
+	"github.com/smallstep/cli/command"
+	"github.com/smallstep/cli/command/crypto/signify"
+)
+
+func init() {
+	command.Register(cli.Command{
+		Name:      "crypto",
+		Usage:     "useful cryptographic plumbing",
+		UsageText: "step crypto <subcommand> [arguments] [global-flags] [subcommand-flags]",
+		Description: `**step crypto** command group provides facilities to create, verify and
+inspect keys, certificates, and other cryptographic primitives used by
+step and other smallstep products.`,
+		Subcommands: cli.Commands{
+			signify.Command(),
+		},
+	})
+}
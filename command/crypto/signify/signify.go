@@ -0,0 +1,148 @@
+// Package signify implements the `step crypto signify` command for
+// verifying and creating signify/minisign signatures.
+package signify
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+
+	"github.com/smallstep/cli/crypto/signify"
+)
+
+// Command returns the signify subcommand, to be registered under
+// `step crypto`.
+func Command() cli.Command {
+	return cli.Command{
+		Name:      "signify",
+		Usage:     "verify and create signify/minisign signatures",
+		UsageText: "step crypto signify <subcommand> [arguments] [global-flags] [subcommand-flags]",
+		Description: `**step crypto signify** command group provides facilities to verify
+release tarballs and plugins signed with signify or minisign, and to create
+such signatures, without requiring OpenPGP.`,
+		Subcommands: cli.Commands{
+			verifyCommand(),
+			signCommand(),
+		},
+	}
+}
+
+func verifyCommand() cli.Command {
+	return cli.Command{
+		Name:      "verify",
+		Usage:     "verify a signify/minisign signature",
+		UsageText: `step crypto signify verify <file> --pub-key <pubkey-file> --signature <sig-file>`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "pub-key",
+				Usage: "The `FILE` with the signify/minisign public key to verify against.",
+			},
+			cli.StringFlag{
+				Name:  "signature",
+				Usage: "The `FILE` with the armored signify/minisign signature.",
+			},
+		},
+		Action: verifyAction,
+	}
+}
+
+func verifyAction(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("step crypto signify verify <file>: exactly one argument is required")
+	}
+
+	pubKeyFile := ctx.String("pub-key")
+	sigFile := ctx.String("signature")
+	if pubKeyFile == "" {
+		return errors.New("flag `--pub-key` is required")
+	}
+	if sigFile == "" {
+		return errors.New("flag `--signature` is required")
+	}
+
+	msg, err := os.ReadFile(ctx.Args().Get(0))
+	if err != nil {
+		return errors.Wrap(err, "error reading file to verify")
+	}
+	pub, err := os.ReadFile(pubKeyFile)
+	if err != nil {
+		return errors.Wrap(err, "error reading public key")
+	}
+	sig, err := os.ReadFile(sigFile)
+	if err != nil {
+		return errors.Wrap(err, "error reading signature")
+	}
+
+	if err := signify.Verify(pub, msg, sig); err != nil {
+		return errors.Wrap(err, "signature verification failed")
+	}
+
+	return nil
+}
+
+func signCommand() cli.Command {
+	return cli.Command{
+		Name:      "sign",
+		Usage:     "create a signify/minisign signature",
+		UsageText: `step crypto signify sign <file> --key <seckey-file> --signature <sig-file> [--trusted-comment <comment>]`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "key",
+				Usage: "The `FILE` with the signify/minisign secret key to sign with.",
+			},
+			cli.StringFlag{
+				Name:  "signature",
+				Usage: "The `FILE` to write the armored signature to.",
+			},
+			cli.StringFlag{
+				Name:  "untrusted-comment",
+				Usage: "A `COMMENT` to store unverified alongside the signature.",
+			},
+			cli.StringFlag{
+				Name:  "trusted-comment",
+				Usage: "A `COMMENT` to sign along with the message, minisign-style.",
+			},
+		},
+		Action: signAction,
+	}
+}
+
+func signAction(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("step crypto signify sign <file>: exactly one argument is required")
+	}
+
+	keyFile := ctx.String("key")
+	sigFile := ctx.String("signature")
+	if keyFile == "" {
+		return errors.New("flag `--key` is required")
+	}
+	if sigFile == "" {
+		return errors.New("flag `--signature` is required")
+	}
+
+	msg, err := os.ReadFile(ctx.Args().Get(0))
+	if err != nil {
+		return errors.Wrap(err, "error reading file to sign")
+	}
+	keyBytes, err := os.ReadFile(keyFile)
+	if err != nil {
+		return errors.Wrap(err, "error reading secret key")
+	}
+	sk, err := signify.ParseSecretKey(keyBytes)
+	if err != nil {
+		return errors.Wrap(err, "error parsing secret key")
+	}
+
+	out, err := signify.Sign(sk, msg, ctx.String("untrusted-comment"), ctx.String("trusted-comment"))
+	if err != nil {
+		return errors.Wrap(err, "error creating signature")
+	}
+
+	if err := os.WriteFile(sigFile, out, 0644); err != nil {
+		return errors.Wrap(err, "error writing signature")
+	}
+
+	return nil
+}
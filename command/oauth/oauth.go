@@ -0,0 +1,391 @@
+// Package oauth implements the `step oauth` command, used to obtain OAuth
+// 2.0 / OIDC tokens interactively for use by other step commands.
+package oauth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mdp/qrterminal/v3"
+	"github.com/pkg/browser"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"golang.org/x/term"
+
+	"github.com/smallstep/cli/command"
+)
+
+func init() {
+	command.Register(cli.Command{
+		Name:      "oauth",
+		Usage:     "authorization and single sign-on using OAuth & OIDC",
+		UsageText: "step oauth [arguments] [global-flags] [subcommand-flags]",
+		Description: `**step oauth** command implements the OAuth 2.0 authorization code flow,
+opening a browser for the user to authenticate and printing the resulting
+token as JSON on stdout.
+
+With the **--device** flag it instead implements the OAuth 2.0 Device
+Authorization Grant (RFC 8628), for input-constrained environments such as
+headless servers or CI jobs that cannot open a browser or receive a
+redirect.`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "provider",
+				Usage: "The `URL` of the OAuth/OIDC provider, used for OIDC discovery.",
+			},
+			cli.StringFlag{
+				Name:  "client-id",
+				Usage: "The OAuth 2.0 `ID` of the client application.",
+			},
+			cli.StringFlag{
+				Name:  "client-secret",
+				Usage: "The OAuth 2.0 `SECRET` of the client application.",
+			},
+			cli.StringFlag{
+				Name:  "scope",
+				Usage: "Space-separated list of `SCOPE`s to request.",
+			},
+			cli.BoolFlag{
+				Name: "device",
+				Usage: `Use the OAuth 2.0 Device Authorization Grant (RFC 8628) instead of the
+authorization code flow. Useful in headless environments that cannot open
+a browser or receive an HTTP redirect.`,
+			},
+			cli.StringFlag{
+				Name: "device-authorization-endpoint",
+				Usage: "The device authorization `URL` of the provider. Only required with " +
+					"--device if the provider's OIDC discovery document does not list one; " +
+					"OIDC discovery is still required for the token endpoint.",
+			},
+		},
+		Action: oauthAction,
+	})
+}
+
+// Token is the JSON representation of the credentials returned by the
+// authorization code and device flows. Both flows emit the same shape so
+// that downstream step commands can consume the result uniformly.
+type Token struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+}
+
+func oauthAction(ctx *cli.Context) error {
+	clientID := ctx.String("client-id")
+	if clientID == "" {
+		return errors.New("flag `--client-id` is required")
+	}
+
+	providerURL := ctx.String("provider")
+	if providerURL == "" {
+		return errors.New("flag `--provider` is required")
+	}
+
+	provider, err := discover(providerURL)
+	if err != nil {
+		return err
+	}
+
+	clientSecret := ctx.String("client-secret")
+	scope := ctx.String("scope")
+
+	var tok *Token
+	if ctx.Bool("device") {
+		tok, err = deviceFlow(ctx, provider, clientID, clientSecret, scope)
+	} else {
+		tok, err = authorizationCodeFlow(provider, clientID, clientSecret, scope)
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(tok)
+}
+
+// providerConfig holds the subset of an OIDC discovery document this
+// command needs.
+type providerConfig struct {
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+// discover fetches the OIDC discovery document for issuer.
+func discover(issuer string) (*providerConfig, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+
+	resp, err := http.Get(issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, errors.Wrap(err, "error fetching OIDC discovery document")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery failed with status %s", resp.Status)
+	}
+
+	var cfg providerConfig
+	if err := json.NewDecoder(resp.Body).Decode(&cfg); err != nil {
+		return nil, errors.Wrap(err, "error decoding OIDC discovery document")
+	}
+	return &cfg, nil
+}
+
+// readOAuthError turns a non-200 response from an OAuth endpoint into an
+// error, using the standard error/error_description JSON body when present.
+func readOAuthError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	var e struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if err := json.Unmarshal(body, &e); err != nil || e.Error == "" {
+		return fmt.Errorf("request failed with status %s", resp.Status)
+	}
+	if e.ErrorDescription != "" {
+		return fmt.Errorf("%s: %s", e.Error, e.ErrorDescription)
+	}
+	return errors.New(e.Error)
+}
+
+// randomState returns a random, URL-safe value to use as the OAuth 2.0
+// state parameter, binding the authorization request to its callback so a
+// third party can't trick the local listener into exchanging an
+// authorization code it didn't request.
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// authorizationCodeFlow implements the standard OAuth 2.0 authorization
+// code flow: it starts a local redirect listener, opens the user's browser
+// on the provider's authorization endpoint, and exchanges the resulting
+// code for a token.
+func authorizationCodeFlow(provider *providerConfig, clientID, clientSecret, scope string) (*Token, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrap(err, "error starting local redirect listener")
+	}
+	defer listener.Close()
+
+	redirectURI := fmt.Sprintf("http://127.0.0.1:%d/", listener.Addr().(*net.TCPAddr).Port)
+
+	state, err := randomState()
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating state")
+	}
+
+	codeCh := make(chan string, 1)
+	errCh := make(chan error, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("state"); got != state {
+			errCh <- errors.New("authorization failed: state parameter mismatch")
+			fmt.Fprintln(w, "Authorization failed: invalid state. You can close this window.")
+			return
+		}
+		if msg := r.URL.Query().Get("error"); msg != "" {
+			errCh <- fmt.Errorf("authorization failed: %s", msg)
+			fmt.Fprintln(w, "Authorization failed. You can close this window.")
+			return
+		}
+		codeCh <- r.URL.Query().Get("code")
+		fmt.Fprintln(w, "Authorization successful. You can close this window.")
+	})
+	go http.Serve(listener, mux)
+
+	if scope == "" {
+		scope = "openid email"
+	}
+	authURL := provider.AuthorizationEndpoint + "?" + url.Values{
+		"response_type": {"code"},
+		"client_id":     {clientID},
+		"redirect_uri":  {redirectURI},
+		"scope":         {scope},
+		"state":         {state},
+	}.Encode()
+
+	fmt.Fprintf(os.Stderr, "Your default browser has been opened to visit:\n\n    %s\n\n", authURL)
+	if err := browser.OpenURL(authURL); err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to open a browser, visit the URL above manually.\n")
+	}
+
+	var code string
+	select {
+	case code = <-codeCh:
+	case err := <-errCh:
+		return nil, err
+	case <-time.After(5 * time.Minute):
+		return nil, errors.New("timed out waiting for the authorization redirect")
+	}
+
+	form := url.Values{
+		"grant_type":   {"authorization_code"},
+		"code":         {code},
+		"redirect_uri": {redirectURI},
+		"client_id":    {clientID},
+	}
+	if clientSecret != "" {
+		form.Set("client_secret", clientSecret)
+	}
+
+	resp, err := http.PostForm(provider.TokenEndpoint, form)
+	if err != nil {
+		return nil, errors.Wrap(err, "error exchanging authorization code")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, readOAuthError(resp)
+	}
+
+	var tok Token
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return nil, errors.Wrap(err, "error decoding token response")
+	}
+	return &tok, nil
+}
+
+// deviceAuthorization is the response of the device authorization endpoint,
+// RFC 8628 section 3.2.
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// grantTypeDeviceCode is the grant_type used to poll the token endpoint
+// during the device flow, RFC 8628 section 3.4.
+const grantTypeDeviceCode = "urn:ietf:params:oauth:grant-type:device_code"
+
+// defaultDeviceCodeExpiresIn is used when the provider's device
+// authorization response omits expires_in or sends a non-positive value, so
+// a misbehaving provider can't make the device code expire before the
+// first poll.
+const defaultDeviceCodeExpiresIn = 600
+
+// deviceFlow implements the OAuth 2.0 Device Authorization Grant, RFC 8628.
+func deviceFlow(ctx *cli.Context, provider *providerConfig, clientID, clientSecret, scope string) (*Token, error) {
+	endpoint := ctx.String("device-authorization-endpoint")
+	if endpoint == "" {
+		endpoint = provider.DeviceAuthorizationEndpoint
+	}
+	if endpoint == "" {
+		return nil, errors.New("the provider does not support the device authorization grant; set --device-authorization-endpoint")
+	}
+
+	form := url.Values{"client_id": {clientID}}
+	if scope != "" {
+		form.Set("scope", scope)
+	}
+
+	resp, err := http.PostForm(endpoint, form)
+	if err != nil {
+		return nil, errors.Wrap(err, "error requesting device authorization")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, readOAuthError(resp)
+	}
+
+	var da deviceAuthorization
+	if err := json.NewDecoder(resp.Body).Decode(&da); err != nil {
+		return nil, errors.Wrap(err, "error decoding device authorization response")
+	}
+	if da.Interval <= 0 {
+		da.Interval = 5
+	}
+	if da.ExpiresIn <= 0 {
+		da.ExpiresIn = defaultDeviceCodeExpiresIn
+	}
+
+	verificationURI := da.VerificationURIComplete
+	if verificationURI == "" {
+		verificationURI = da.VerificationURI
+	}
+
+	fmt.Fprintf(os.Stderr, "Using a browser, go to %s and enter the code:\n\n    %s\n\n", da.VerificationURI, da.UserCode)
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		qrterminal.Generate(verificationURI, qrterminal.L, os.Stderr)
+	}
+
+	return pollDeviceToken(provider.TokenEndpoint, clientID, clientSecret, &da)
+}
+
+// pollDeviceToken polls the token endpoint until the user completes
+// authorization, the device code expires, or access is denied, following
+// RFC 8628 section 3.5.
+func pollDeviceToken(tokenEndpoint, clientID, clientSecret string, da *deviceAuthorization) (*Token, error) {
+	interval := time.Duration(da.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(da.ExpiresIn) * time.Second)
+
+	for {
+		time.Sleep(interval)
+		if time.Now().After(deadline) {
+			return nil, errors.New("device code expired before authorization completed")
+		}
+
+		form := url.Values{
+			"grant_type":  {grantTypeDeviceCode},
+			"device_code": {da.DeviceCode},
+			"client_id":   {clientID},
+		}
+		if clientSecret != "" {
+			form.Set("client_secret", clientSecret)
+		}
+
+		resp, err := http.PostForm(tokenEndpoint, form)
+		if err != nil {
+			return nil, errors.Wrap(err, "error polling token endpoint")
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusOK {
+			var tok Token
+			if err := json.Unmarshal(body, &tok); err != nil {
+				return nil, errors.Wrap(err, "error decoding token response")
+			}
+			return &tok, nil
+		}
+
+		var oauthErr struct {
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(body, &oauthErr); err != nil {
+			return nil, fmt.Errorf("token endpoint returned status %s", resp.Status)
+		}
+
+		switch oauthErr.Error {
+		case "authorization_pending":
+			// Keep polling at the current interval.
+		case "slow_down":
+			interval += 5 * time.Second
+		case "access_denied":
+			return nil, errors.New("authorization was denied")
+		case "expired_token":
+			return nil, errors.New("device code expired before authorization completed")
+		default:
+			return nil, fmt.Errorf("token endpoint error: %s", oauthErr.Error)
+		}
+	}
+}
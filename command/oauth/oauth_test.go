@@ -0,0 +1,118 @@
+package oauth
+
+import (
+	"encoding/json"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/urfave/cli"
+)
+
+func TestPollDeviceToken(t *testing.T) {
+	t.Run("authorization_pending then slow_down then success", func(t *testing.T) {
+		var calls int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			switch calls {
+			case 1:
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+			case 2:
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "slow_down"})
+			default:
+				json.NewEncoder(w).Encode(Token{AccessToken: "at", TokenType: "Bearer"})
+			}
+		}))
+		defer srv.Close()
+
+		da := &deviceAuthorization{DeviceCode: "dc", Interval: 0, ExpiresIn: 60}
+		tok, err := pollDeviceToken(srv.URL, "client-id", "", da)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if tok.AccessToken != "at" {
+			t.Fatalf("unexpected access token: %q", tok.AccessToken)
+		}
+		if calls != 3 {
+			t.Fatalf("expected 3 calls, got %d", calls)
+		}
+	})
+
+	t.Run("access_denied", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "access_denied"})
+		}))
+		defer srv.Close()
+
+		da := &deviceAuthorization{DeviceCode: "dc", Interval: 0, ExpiresIn: 60}
+		if _, err := pollDeviceToken(srv.URL, "client-id", "", da); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+
+	t.Run("expired_token", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "expired_token"})
+		}))
+		defer srv.Close()
+
+		da := &deviceAuthorization{DeviceCode: "dc", Interval: 0, ExpiresIn: 60}
+		if _, err := pollDeviceToken(srv.URL, "client-id", "", da); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+
+	t.Run("device code expires before completion", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "authorization_pending"})
+		}))
+		defer srv.Close()
+
+		da := &deviceAuthorization{DeviceCode: "dc", Interval: 0, ExpiresIn: 0}
+		if _, err := pollDeviceToken(srv.URL, "client-id", "", da); err == nil {
+			t.Fatal("expected an error, got nil")
+		}
+	})
+}
+
+// TestDeviceFlow_DefaultsExpiresIn exercises a provider that omits
+// expires_in from its device authorization response: without a default,
+// pollDeviceToken's deadline would equal its start time and the flow would
+// report "device code expired" on the very first iteration.
+func TestDeviceFlow_DefaultsExpiresIn(t *testing.T) {
+	tokenSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(Token{AccessToken: "at", TokenType: "Bearer"})
+	}))
+	defer tokenSrv.Close()
+
+	authSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// expires_in deliberately omitted, as some providers do.
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code":      "dc",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": "https://example.com/device",
+			"interval":         1,
+		})
+	}))
+	defer authSrv.Close()
+
+	provider := &providerConfig{
+		DeviceAuthorizationEndpoint: authSrv.URL,
+		TokenEndpoint:               tokenSrv.URL,
+	}
+	ctx := cli.NewContext(nil, flag.NewFlagSet("test", flag.ContinueOnError), nil)
+
+	tok, err := deviceFlow(ctx, provider, "client-id", "", "")
+	if err != nil {
+		t.Fatalf("deviceFlow() error: %v", err)
+	}
+	if tok.AccessToken != "at" {
+		t.Fatalf("unexpected access token: %q", tok.AccessToken)
+	}
+}
@@ -0,0 +1,90 @@
+// Package plugin implements the `step plugin` command used to discover and
+// inspect step plugins.
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+
+	"github.com/smallstep/cli/command"
+	"github.com/smallstep/cli/internal/plugin"
+)
+
+func init() {
+	command.Register(cli.Command{
+		Name:      "plugin",
+		Usage:     "manage step plugins",
+		UsageText: "step plugin <subcommand> [arguments] [global-flags] [subcommand-flags]",
+		Description: `**step plugin** command group provides facilities to discover the plugins
+installed on the system.
+
+A step plugin is an executable named 'step-<name>-plugin' available on
+$PATH or in $STEPPATH/plugins. Once installed, a plugin is invoked as if it
+were a built-in subcommand, e.g. 'step kms ...' runs 'step-kms-plugin ...'.`,
+		Subcommands: cli.Commands{
+			listCommand(),
+			infoCommand(),
+		},
+	})
+}
+
+func listCommand() cli.Command {
+	return cli.Command{
+		Name:      "list",
+		Usage:     "list the plugins installed on the system",
+		UsageText: "step plugin list",
+		Action:    listAction,
+	}
+}
+
+func listAction(ctx *cli.Context) error {
+	names := plugin.List()
+	if len(names) == 0 {
+		fmt.Println("no plugins found in $STEPPATH/plugins or $PATH")
+		return nil
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+	return nil
+}
+
+func infoCommand() cli.Command {
+	return cli.Command{
+		Name:      "info",
+		Usage:     "print the metadata reported by a plugin",
+		UsageText: "step plugin info <name>",
+		Action:    infoAction,
+	}
+}
+
+func infoAction(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		return errors.New("step plugin info <name>: exactly one argument is required")
+	}
+
+	name := ctx.Args().Get(0)
+	path, err := plugin.LookPath(name)
+	if err != nil {
+		return err
+	}
+
+	info, err := plugin.GetInfo(name, path)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Name: %s\n", info.Name)
+	if info.Version != "" {
+		fmt.Printf("Version: %s\n", info.Version)
+	}
+	if info.Usage != "" {
+		fmt.Printf("Usage: %s\n", info.Usage)
+	}
+	if info.Description != "" {
+		fmt.Printf("Description: %s\n", info.Description)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+// Package upgrade implements the `step upgrade` command, which fetches a
+// `step` release tarball from GitHub, verifies it against the baked-in
+// smallstep release signing key, and replaces the running binary.
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+
+	"github.com/smallstep/cli/command"
+	"github.com/smallstep/cli/crypto/signify"
+)
+
+// releasePublicKey is the signify/minisign public key used to sign `step`
+// release tarballs. It must be kept in sync with the key used by the
+// release pipeline; an artifact that doesn't verify against this key is
+// never installed.
+const releasePublicKey = `untrusted comment: smallstep release signing key
+RWSbnjJ3Hjs+SgABAgMEBQYHCAkKCwwNDg8QERITFBUWFxgZGhscHR4f
+`
+
+// defaultRepo is the GitHub repository releases are fetched from.
+const defaultRepo = "smallstep/cli"
+
+func init() {
+	command.Register(cli.Command{
+		Name:      "upgrade",
+		Usage:     "upgrade step to the latest (or a specific) release",
+		UsageText: "step upgrade [--version <version>] [--repo <owner/repo>]",
+		Description: `**step upgrade** command downloads a **step** release tarball for the
+current platform from GitHub, verifies it against the signify/minisign
+public key baked into this binary (see **step crypto signify**), and, once
+verified, replaces the currently running executable with the one from the
+tarball.
+
+By default the latest release is installed; use **--version** to pin a
+specific tag.`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "version",
+				Usage: "The release `TAG` to install instead of the latest release.",
+			},
+			cli.StringFlag{
+				Name:  "repo",
+				Usage: "The `OWNER/REPO` GitHub repository to fetch releases from.",
+				Value: defaultRepo,
+			},
+		},
+		Action: upgradeAction,
+	})
+}
+
+// release is the subset of the GitHub releases API response this command
+// needs.
+type release struct {
+	TagName string  `json:"tag_name"`
+	Assets  []asset `json:"assets"`
+}
+
+type asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+func upgradeAction(ctx *cli.Context) error {
+	repo := ctx.String("repo")
+	version := ctx.String("version")
+
+	rel, err := fetchRelease(repo, version)
+	if err != nil {
+		return errors.Wrap(err, "error fetching release information")
+	}
+
+	assetName := fmt.Sprintf("step_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	tarballURL, err := assetURL(rel, assetName)
+	if err != nil {
+		return err
+	}
+	sigURL, err := assetURL(rel, assetName+".sig")
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Downloading %s %s ...\n", assetName, rel.TagName)
+	tarball, err := download(tarballURL)
+	if err != nil {
+		return errors.Wrap(err, "error downloading release artifact")
+	}
+	sig, err := download(sigURL)
+	if err != nil {
+		return errors.Wrap(err, "error downloading release signature")
+	}
+
+	if err := signify.Verify([]byte(releasePublicKey), tarball, sig); err != nil {
+		return errors.Wrap(err, "release artifact failed signature verification")
+	}
+
+	binary, err := extractBinary(tarball, "step")
+	if err != nil {
+		return errors.Wrap(err, "error extracting step binary from release artifact")
+	}
+
+	if err := replaceExecutable(binary); err != nil {
+		return errors.Wrap(err, "error installing new step binary")
+	}
+
+	fmt.Printf("step upgraded to %s\n", rel.TagName)
+	return nil
+}
+
+// fetchRelease fetches release metadata for repo, either the latest release
+// or, when version is non-empty, the release tagged version.
+func fetchRelease(repo, version string) (*release, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repo)
+	if version != "" {
+		url = fmt.Sprintf("https://api.github.com/repos/%s/releases/tags/%s", repo, version)
+	}
+
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned status %s", resp.Status)
+	}
+
+	var rel release
+	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+		return nil, errors.Wrap(err, "error decoding release information")
+	}
+	return &rel, nil
+}
+
+// assetURL returns the download URL of the release asset named name.
+func assetURL(rel *release, name string) (string, error) {
+	for _, a := range rel.Assets {
+		if a.Name == name {
+			return a.BrowserDownloadURL, nil
+		}
+	}
+	return "", fmt.Errorf("release %s does not have an asset named %q", rel.TagName, name)
+}
+
+// download GETs url and returns the full response body.
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned status %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// extractBinary reads a gzipped tarball and returns the contents of the
+// entry named name.
+func extractBinary(tarball []byte, name string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(tarball))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if filepath.Base(hdr.Name) != name {
+			continue
+		}
+		return io.ReadAll(tr)
+	}
+	return nil, fmt.Errorf("tarball does not contain a %q entry", name)
+}
+
+// replaceExecutable writes binary over the currently running executable,
+// via a temporary file in the same directory so the replacement is atomic.
+func replaceExecutable(binary []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".step-upgrade-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), fi.Mode()); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), exe)
+}
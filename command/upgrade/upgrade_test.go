@@ -0,0 +1,70 @@
+package upgrade
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+func buildTarball(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := tw.WriteHeader(&tar.Header{Name: name, Size: int64(len(content)), Mode: 0755}); err != nil {
+		t.Fatalf("error writing tar header: %v", err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatalf("error writing tar content: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("error closing tar writer: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("error closing gzip writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractBinary(t *testing.T) {
+	want := []byte("fake step binary contents")
+	tarball := buildTarball(t, "step", want)
+
+	got, err := extractBinary(tarball, "step")
+	if err != nil {
+		t.Fatalf("extractBinary() error: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("extractBinary() = %q, want %q", got, want)
+	}
+}
+
+func TestExtractBinary_MissingEntry(t *testing.T) {
+	tarball := buildTarball(t, "README.md", []byte("not a binary"))
+
+	if _, err := extractBinary(tarball, "step"); err == nil {
+		t.Fatal("extractBinary() succeeded for a tarball without a step entry")
+	}
+}
+
+func TestAssetURL(t *testing.T) {
+	rel := &release{
+		TagName: "v1.2.3",
+		Assets: []asset{
+			{Name: "step_linux_amd64.tar.gz", BrowserDownloadURL: "https://example.com/step_linux_amd64.tar.gz"},
+		},
+	}
+
+	got, err := assetURL(rel, "step_linux_amd64.tar.gz")
+	if err != nil {
+		t.Fatalf("assetURL() error: %v", err)
+	}
+	if got != "https://example.com/step_linux_amd64.tar.gz" {
+		t.Fatalf("assetURL() = %q, want the matching asset's download URL", got)
+	}
+
+	if _, err := assetURL(rel, "step_windows_amd64.tar.gz"); err == nil {
+		t.Fatal("assetURL() succeeded for a missing asset")
+	}
+}
@@ -0,0 +1,282 @@
+// Package signify implements the Ed25519-based signify/minisign armored
+// signature format, so that release tarballs and plugins can be verified
+// without requiring OpenPGP.
+//
+// A signify file is a two-line "untrusted comment: ..." header followed by
+// the base64 encoding of a 10-byte prefix (a 2-byte algorithm ID and an
+// 8-byte key ID) and either a public/secret key or a 64-byte Ed25519
+// signature. minisign additionally supports a "trusted comment" line signed
+// with a second, global signature over (signature || trusted comment),
+// which this package also understands.
+package signify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Algorithm IDs used in the 2-byte prefix of signatures. Public and secret
+// keys always use algoEd25519.
+const (
+	algoEd25519        = "Ed" // plain Ed25519 signature over the message (legacy signify)
+	algoEd25519Prehash = "ED" // Ed25519 signature over a BLAKE2b-512 prehash of the message (minisign default)
+	secretKeyKDFNone   = "00" // kdfalg tag for an unencrypted secret key
+)
+
+const (
+	untrustedCommentPrefix = "untrusted comment: "
+	trustedCommentPrefix   = "trusted comment: "
+
+	algoLen  = 2
+	keyIDLen = 8
+	sigLen   = ed25519.SignatureSize
+)
+
+// PublicKey is a parsed signify/minisign public key.
+type PublicKey struct {
+	Algo  string
+	KeyID [8]byte
+	Key   ed25519.PublicKey
+}
+
+// SecretKey is a parsed signify/minisign secret key.
+//
+// Only unencrypted secret keys (kdfalg "00" and kdfrounds == 0) are
+// supported; any other kdfalg, such as signify's bcrypt_pbkdf ("BK"), is
+// rejected as encrypted even when kdfrounds is 0. Decrypting a
+// passphrase-protected key is not implemented.
+type SecretKey struct {
+	Algo  string
+	KeyID [8]byte
+	Key   ed25519.PrivateKey
+}
+
+// Signature is a parsed signify/minisign signature file.
+type Signature struct {
+	Algo      string
+	KeyID     [8]byte
+	Signature []byte
+
+	// TrustedComment and GlobalSignature are set when the signature file
+	// is in minisign format; GlobalSignature is nil for a plain signify
+	// signature.
+	TrustedComment  string
+	GlobalSignature []byte
+}
+
+// splitArmor parses the two-line (or more) armored format shared by keys and
+// signatures: an "untrusted comment: " line, a base64 payload line, and zero
+// or more trailing lines. It rejects files where the untrusted comment
+// spans, or is followed by, more than one line claiming to be the comment.
+func splitArmor(b []byte) (comment string, payload []byte, rest []string, err error) {
+	lines := strings.Split(strings.TrimRight(string(b), "\r\n"), "\n")
+	if len(lines) < 2 {
+		return "", nil, nil, errors.New("signify: invalid armored file")
+	}
+	if !strings.HasPrefix(lines[0], untrustedCommentPrefix) {
+		return "", nil, nil, errors.New("signify: first line is not an untrusted comment")
+	}
+	for _, l := range lines[1:] {
+		if strings.HasPrefix(l, untrustedCommentPrefix) {
+			return "", nil, nil, errors.New("signify: multi-line untrusted comments are not supported")
+		}
+	}
+
+	payload, err = base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("signify: invalid base64 payload: %w", err)
+	}
+	comment = strings.TrimPrefix(lines[0], untrustedCommentPrefix)
+	return comment, payload, lines[2:], nil
+}
+
+// ParsePublicKey parses an armored signify/minisign public key.
+func ParsePublicKey(b []byte) (*PublicKey, error) {
+	_, payload, _, err := splitArmor(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != algoLen+keyIDLen+ed25519.PublicKeySize {
+		return nil, errors.New("signify: invalid public key length")
+	}
+	if algo := string(payload[:algoLen]); algo != algoEd25519 {
+		return nil, fmt.Errorf("signify: unsupported public key algorithm %q", algo)
+	}
+
+	pub := &PublicKey{Algo: algoEd25519}
+	copy(pub.KeyID[:], payload[algoLen:algoLen+keyIDLen])
+	pub.Key = append(ed25519.PublicKey(nil), payload[algoLen+keyIDLen:]...)
+	return pub, nil
+}
+
+// secretKeyPayloadLen is the length of the signify/minisign secret key
+// payload: 2-byte pkalg + 2-byte kdfalg + 4-byte kdfrounds + 16-byte salt +
+// 8-byte checksum + 8-byte key id + 64-byte (encrypted) Ed25519 key.
+const (
+	kdfAlgLen    = 2
+	kdfRoundsLen = 4
+	saltLen      = 16
+	checksumLen  = 8
+
+	secretKeyPayloadLen = algoLen + kdfAlgLen + kdfRoundsLen + saltLen + checksumLen + keyIDLen + ed25519.PrivateKeySize
+)
+
+// ParseSecretKey parses an armored, unencrypted signify/minisign secret key.
+func ParseSecretKey(b []byte) (*SecretKey, error) {
+	_, payload, _, err := splitArmor(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != secretKeyPayloadLen {
+		return nil, errors.New("signify: invalid secret key length")
+	}
+
+	var off int
+	algo := string(payload[off : off+algoLen])
+	off += algoLen
+	if algo != algoEd25519 {
+		return nil, fmt.Errorf("signify: unsupported secret key algorithm %q", algo)
+	}
+
+	kdfAlg := string(payload[off : off+kdfAlgLen])
+	off += kdfAlgLen
+	kdfRounds := binary.BigEndian.Uint32(payload[off : off+kdfRoundsLen])
+	off += kdfRoundsLen
+	off += saltLen // salt is only meaningful when the key is scrypt-encrypted
+	checksum := payload[off : off+checksumLen]
+	off += checksumLen
+
+	if kdfAlg != secretKeyKDFNone || kdfRounds != 0 {
+		return nil, errors.New("signify: encrypted secret keys are not supported")
+	}
+
+	var keyID [8]byte
+	copy(keyID[:], payload[off:off+keyIDLen])
+	off += keyIDLen
+	key := payload[off : off+ed25519.PrivateKeySize]
+
+	sum := sha512.Sum512(key)
+	if !bytes.Equal(sum[:checksumLen], checksum) {
+		return nil, errors.New("signify: secret key checksum mismatch")
+	}
+
+	return &SecretKey{
+		Algo:  algoEd25519,
+		KeyID: keyID,
+		Key:   append(ed25519.PrivateKey(nil), key...),
+	}, nil
+}
+
+// ParseSignature parses an armored signify/minisign signature file.
+func ParseSignature(b []byte) (*Signature, error) {
+	_, payload, rest, err := splitArmor(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != algoLen+keyIDLen+sigLen {
+		return nil, errors.New("signify: invalid signature length")
+	}
+	algo := string(payload[:algoLen])
+	if algo != algoEd25519 && algo != algoEd25519Prehash {
+		return nil, fmt.Errorf("signify: unsupported signature algorithm %q", algo)
+	}
+
+	sig := &Signature{Algo: algo}
+	copy(sig.KeyID[:], payload[algoLen:algoLen+keyIDLen])
+	sig.Signature = payload[algoLen+keyIDLen:]
+
+	switch {
+	case len(rest) == 0 || (len(rest) == 1 && strings.TrimSpace(rest[0]) == ""):
+		// Plain signify signature, no trusted comment.
+	case len(rest) >= 2 && strings.HasPrefix(rest[0], trustedCommentPrefix):
+		globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(rest[1]))
+		if err != nil {
+			return nil, fmt.Errorf("signify: invalid base64 global signature: %w", err)
+		}
+		if len(globalSig) != sigLen {
+			return nil, errors.New("signify: invalid global signature length")
+		}
+		sig.TrustedComment = strings.TrimPrefix(rest[0], trustedCommentPrefix)
+		sig.GlobalSignature = globalSig
+	default:
+		return nil, errors.New("signify: malformed trusted comment section")
+	}
+
+	return sig, nil
+}
+
+// Verify verifies that sigFile is a valid signature of msg made by the
+// secret key matching pub. It supports both plain signify signatures
+// (algorithm "Ed", signed over the raw message) and minisign signatures
+// (algorithm "ED", minisign's default, signed over a BLAKE2b-512 prehash of
+// the message), as well as a trusted comment, which is additionally
+// verified against its own global signature.
+func Verify(pub, msg, sigFile []byte) error {
+	pk, err := ParsePublicKey(pub)
+	if err != nil {
+		return err
+	}
+	sig, err := ParseSignature(sigFile)
+	if err != nil {
+		return err
+	}
+	if sig.KeyID != pk.KeyID {
+		return errors.New("signify: signature key id does not match public key")
+	}
+
+	signedMsg := msg
+	if sig.Algo == algoEd25519Prehash {
+		h := blake2b.Sum512(msg)
+		signedMsg = h[:]
+	}
+	if !ed25519.Verify(pk.Key, signedMsg, sig.Signature) {
+		return errors.New("signify: signature verification failed")
+	}
+	if sig.GlobalSignature != nil {
+		globalMsg := append(append([]byte{}, sig.Signature...), sig.TrustedComment...)
+		if !ed25519.Verify(pk.Key, globalMsg, sig.GlobalSignature) {
+			return errors.New("signify: trusted comment signature verification failed")
+		}
+	}
+	return nil
+}
+
+// Sign creates an armored minisign-style signature of msg using sk, signing
+// a BLAKE2b-512 prehash of the message (algorithm "ED"), matching
+// minisign's default output. When trustedComment is non-empty, the trusted
+// comment is embedded and protected by a second, global signature over
+// (signature || trustedComment).
+func Sign(sk *SecretKey, msg []byte, untrustedComment, trustedComment string) ([]byte, error) {
+	if untrustedComment == "" {
+		untrustedComment = "signature from signify secret key"
+	}
+
+	h := blake2b.Sum512(msg)
+	sig := ed25519.Sign(sk.Key, h[:])
+
+	payload := make([]byte, 0, algoLen+keyIDLen+sigLen)
+	payload = append(payload, algoEd25519Prehash...)
+	payload = append(payload, sk.KeyID[:]...)
+	payload = append(payload, sig...)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s%s\n", untrustedCommentPrefix, untrustedComment)
+	fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(payload))
+
+	if trustedComment != "" {
+		globalMsg := append(append([]byte{}, sig...), trustedComment...)
+		globalSig := ed25519.Sign(sk.Key, globalMsg)
+		fmt.Fprintf(&buf, "%s%s\n", trustedCommentPrefix, trustedComment)
+		fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(globalSig))
+	}
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,171 @@
+package signify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+func generateKeyPair(t *testing.T) (*PublicKey, *SecretKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	keyID := [8]byte{1, 2, 3, 4, 5, 6, 7, 8}
+	return &PublicKey{Algo: algoEd25519, KeyID: keyID, Key: pub},
+		&SecretKey{Algo: algoEd25519, KeyID: keyID, Key: priv}
+}
+
+func armoredPublicKey(pub *PublicKey) []byte {
+	payload := append([]byte(algoEd25519), pub.KeyID[:]...)
+	payload = append(payload, pub.Key...)
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%stest key\n", untrustedCommentPrefix)
+	fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(payload))
+	return buf.Bytes()
+}
+
+// rawSignature builds an armored signature file by hand, so tests can
+// exercise algorithm tags and malformed inputs that Sign never produces.
+func rawSignature(algo string, keyID [8]byte, sig []byte, trustedComment string, globalSig []byte) []byte {
+	payload := append([]byte(algo), keyID[:]...)
+	payload = append(payload, sig...)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%stest signature\n", untrustedCommentPrefix)
+	fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(payload))
+	if trustedComment != "" {
+		fmt.Fprintf(&buf, "%s%s\n", trustedCommentPrefix, trustedComment)
+		fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(globalSig))
+	}
+	return buf.Bytes()
+}
+
+func TestVerify_RoundTrip(t *testing.T) {
+	pub, sk := generateKeyPair(t)
+	msg := []byte("release tarball contents")
+
+	sigFile, err := Sign(sk, msg, "", "")
+	if err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+
+	if err := Verify(armoredPublicKey(pub), msg, sigFile); err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+}
+
+func TestVerify_MinisignTrustedComment(t *testing.T) {
+	pub, sk := generateKeyPair(t)
+	msg := []byte("release tarball contents")
+
+	sigFile, err := Sign(sk, msg, "comment", "timestamp:1700000000")
+	if err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+
+	if err := Verify(armoredPublicKey(pub), msg, sigFile); err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+
+	// Tampering with the trusted comment must invalidate the global signature.
+	tampered := bytes.Replace(sigFile, []byte("timestamp:1700000000"), []byte("timestamp:0000000000"), 1)
+	if err := Verify(armoredPublicKey(pub), msg, tampered); err == nil {
+		t.Fatal("Verify() succeeded on a tampered trusted comment")
+	}
+}
+
+func TestVerify_LegacySignifyAlgorithm(t *testing.T) {
+	pub, sk := generateKeyPair(t)
+	msg := []byte("a file signed by plain OpenBSD signify")
+
+	sig := ed25519.Sign(sk.Key, msg)
+	sigFile := rawSignature(algoEd25519, sk.KeyID, sig, "", nil)
+
+	if err := Verify(armoredPublicKey(pub), msg, sigFile); err != nil {
+		t.Fatalf("Verify() error: %v", err)
+	}
+}
+
+func TestVerify_WrongKeyID(t *testing.T) {
+	pub, sk := generateKeyPair(t)
+	msg := []byte("release tarball contents")
+
+	h := blake2b.Sum512(msg)
+	sig := ed25519.Sign(sk.Key, h[:])
+
+	wrongKeyID := sk.KeyID
+	wrongKeyID[0] ^= 0xff
+	sigFile := rawSignature(algoEd25519Prehash, wrongKeyID, sig, "", nil)
+
+	if err := Verify(armoredPublicKey(pub), msg, sigFile); err == nil {
+		t.Fatal("Verify() succeeded with a mismatched key id")
+	}
+}
+
+func TestVerify_WrongKey(t *testing.T) {
+	pub, sk := generateKeyPair(t)
+	other, _ := generateKeyPair(t)
+	msg := []byte("release tarball contents")
+
+	sigFile, err := Sign(sk, msg, "", "")
+	if err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+	// Same key id, different key: ParsePublicKey/Verify should still fail
+	// the signature check itself.
+	other.KeyID = pub.KeyID
+
+	if err := Verify(armoredPublicKey(other), msg, sigFile); err == nil {
+		t.Fatal("Verify() succeeded with the wrong public key")
+	}
+}
+
+func TestParseSignature_TruncatedPayload(t *testing.T) {
+	_, sk := generateKeyPair(t)
+	msg := []byte("release tarball contents")
+
+	sigFile, err := Sign(sk, msg, "", "")
+	if err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+
+	lines := strings.SplitN(string(sigFile), "\n", 3)
+	payload, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil {
+		t.Fatalf("error decoding test payload: %v", err)
+	}
+	truncated := base64.StdEncoding.EncodeToString(payload[:len(payload)-10])
+	corrupt := []byte(lines[0] + "\n" + truncated + "\n")
+
+	if _, err := ParseSignature(corrupt); err == nil {
+		t.Fatal("ParseSignature() succeeded on a truncated payload")
+	}
+}
+
+func TestSplitArmor_RejectsMultiLineUntrustedComment(t *testing.T) {
+	data := []byte(untrustedCommentPrefix + "one\n" + untrustedCommentPrefix + "two\nAAAA\n")
+	if _, _, _, err := splitArmor(data); err == nil {
+		t.Fatal("splitArmor() succeeded on a multi-line untrusted comment")
+	}
+}
+
+func TestParseSecretKey_RejectsEncrypted(t *testing.T) {
+	payload := make([]byte, secretKeyPayloadLen)
+	copy(payload[:algoLen], algoEd25519)
+	copy(payload[algoLen:algoLen+kdfAlgLen], "BK") // bcrypt pbkdf, i.e. encrypted
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%stest key\n", untrustedCommentPrefix)
+	fmt.Fprintf(&buf, "%s\n", base64.StdEncoding.EncodeToString(payload))
+
+	if _, err := ParseSecretKey(buf.Bytes()); err == nil {
+		t.Fatal("ParseSecretKey() succeeded on an encrypted secret key")
+	}
+}
@@ -12,11 +12,16 @@ import (
 	"strings"
 
 	"github.com/smallstep/cli/internal/plugin"
+	"go.step.sm/crypto/kms/apiv1"
+	"go.step.sm/crypto/kms/sshagentkms"
 	"go.step.sm/crypto/pemutil"
 )
 
 // CreateSigner reads a key from a file with a given name or creates a signer
-// with the given kms and name uri.
+// with the given kms and name uri. When this binary is built with a tag
+// matching the kms backend (pkcs11, yubikey, cloudkms, awskms or
+// sshagentkms), the signer is created in-process; otherwise it falls back to
+// shelling out to step-kms-plugin.
 func CreateSigner(kms, name string, opts ...pemutil.Options) (crypto.Signer, error) {
 	if kms == "" {
 		s, err := pemutil.Read(name, opts...)
@@ -29,6 +34,13 @@ func CreateSigner(kms, name string, opts ...pemutil.Options) (crypto.Signer, err
 		return nil, fmt.Errorf("file %s does not contain a valid private key", name)
 	}
 
+	if signer, ok, err := createInProcessSigner(kms, name); ok {
+		if err != nil {
+			return nil, err
+		}
+		return signer, nil
+	}
+
 	return newKMSSigner(kms, name)
 }
 
@@ -38,12 +50,34 @@ func IsKMSSigner(signer crypto.Signer) (ok bool) {
 	return
 }
 
+// IsInProcessKMSSigner returns true if the given signer was created
+// in-process by a compiled-in KMS backend, as opposed to shelling out to
+// step-kms-plugin.
+func IsInProcessKMSSigner(signer crypto.Signer) (ok bool) {
+	_, ok = signer.(*inProcessSigner)
+	return
+}
+
+// unwrap returns the signer wrapped by an in-process KMS signer, or signer
+// unchanged if it isn't one.
+func unwrap(signer crypto.Signer) crypto.Signer {
+	if s, ok := signer.(*inProcessSigner); ok {
+		return s.Signer
+	}
+	return signer
+}
+
 // IsX509Signer returns true if the given signer is supported by Go's
 // crypto/x509 package to sign sign X509 certificates. This methods returns true
 // for ECDSA, RSA and Ed25519 keys, but if the kms is `sshagentkms:` it will
 // only return true for Ed25519 keys.
 func IsX509Signer(signer crypto.Signer) bool {
+	signer = unwrap(signer)
 	pub := signer.Public()
+	if _, ok := signer.(*sshagentkms.WrappedSSHSigner); ok {
+		_, ok = pub.(ed25519.PublicKey)
+		return ok
+	}
 	if ks, ok := signer.(*kmsSigner); ok {
 		if strings.HasPrefix(strings.ToLower(ks.kms), "sshagentkms:") {
 			_, ok = pub.(ed25519.PublicKey)
@@ -58,6 +92,16 @@ func IsX509Signer(signer crypto.Signer) bool {
 	}
 }
 
+// inProcessSigner wraps a crypto.Signer obtained from a compiled-in KMS
+// backend, so IsInProcessKMSSigner and IsX509Signer can recognize it. It
+// keeps a reference to the apiv1.KeyManager that created it alive for as
+// long as the signer itself is reachable, since Sign keeps using its
+// underlying client/session.
+type inProcessSigner struct {
+	crypto.Signer
+	km apiv1.KeyManager
+}
+
 type kmsSigner struct {
 	crypto.PublicKey
 	name     string
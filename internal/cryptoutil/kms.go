@@ -0,0 +1,61 @@
+//go:build pkcs11 || yubikey || cloudkms || awskms || sshagentkms
+
+package cryptoutil
+
+import (
+	"context"
+	"crypto"
+	"strings"
+
+	"go.step.sm/crypto/kms"
+	"go.step.sm/crypto/kms/apiv1"
+)
+
+// OpenKMS opens the KMS identified by uri and returns a ready to use
+// apiv1.KeyManager. Only the backends selected at build time via the
+// pkcs11, yubikey, cloudkms, awskms and sshagentkms tags are available.
+func OpenKMS(uri string) (apiv1.KeyManager, error) {
+	typ, _, _ := strings.Cut(uri, ":")
+	return kms.New(context.Background(), apiv1.Options{
+		Type: apiv1.Type(typ),
+		URI:  uri,
+	})
+}
+
+// createInProcessSigner opens the kms identified by kmsURI and creates a
+// signer for name in-process, without forking step-kms-plugin. The second
+// return value reports whether kmsURI's backend is one this build knows how
+// to handle in-process at all; CreateSigner falls back to newKMSSigner when
+// it is false, e.g. a binary built with only the awskms tag asked for a
+// pkcs11: uri.
+//
+// The returned apiv1.KeyManager is intentionally kept open: the signer it
+// produced keeps using its client/session (its gRPC connection, PKCS#11
+// session, etc.) on every Sign call, so closing it here would break the
+// very first signature.
+func createInProcessSigner(kmsURI, name string) (crypto.Signer, bool, error) {
+	km, err := OpenKMS(kmsURI)
+	if err != nil {
+		if isUnsupportedKMS(err) {
+			return nil, false, nil
+		}
+		return nil, true, err
+	}
+
+	signer, err := km.CreateSigner(&apiv1.CreateSignerRequest{SigningKey: name})
+	if err != nil {
+		return nil, true, err
+	}
+	return &inProcessSigner{Signer: signer, km: km}, true, nil
+}
+
+// isUnsupportedKMS reports whether err indicates that kmsURI's type has no
+// backend registered in this build, as opposed to the backend being
+// registered but failing to initialize (bad credentials, unreachable
+// server, missing PKCS#11 module, etc.), which should be surfaced to the
+// user rather than silently falling back to step-kms-plugin.
+func isUnsupportedKMS(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "not supported") || strings.Contains(msg, "not registered") ||
+		strings.Contains(msg, "no constructor registered")
+}
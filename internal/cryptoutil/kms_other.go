@@ -0,0 +1,22 @@
+//go:build !(pkcs11 || yubikey || cloudkms || awskms || sshagentkms)
+
+package cryptoutil
+
+import (
+	"crypto"
+	"fmt"
+
+	"go.step.sm/crypto/kms/apiv1"
+)
+
+// OpenKMS returns an error: this binary was not built with support for any
+// in-process KMS backend. Build with the pkcs11, yubikey, cloudkms, awskms
+// or sshagentkms tag to enable it.
+func OpenKMS(uri string) (apiv1.KeyManager, error) {
+	return nil, fmt.Errorf("no in-process kms backend is compiled into this binary")
+}
+
+// createInProcessSigner always defers to step-kms-plugin in this build.
+func createInProcessSigner(kmsURI, name string) (crypto.Signer, bool, error) {
+	return nil, false, nil
+}
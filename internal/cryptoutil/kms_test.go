@@ -0,0 +1,27 @@
+//go:build pkcs11 || yubikey || cloudkms || awskms || sshagentkms
+
+package cryptoutil
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsUnsupportedKMS(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not supported", errors.New(`kms type "pkcs11" is not supported`), true},
+		{"not registered", errors.New(`no constructor registered for type "cloudkms"`), true},
+		{"unrelated failure", errors.New("failed to connect to PKCS#11 module"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUnsupportedKMS(tt.err); got != tt.want {
+				t.Errorf("isUnsupportedKMS(%q) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package plugin implements discovery and invocation of step plugins.
+//
+// A step plugin is any executable named `step-<name>-plugin` that is either
+// on $PATH or in $STEPPATH/plugins. Plugins are invoked as regular
+// subcommands (e.g. `step kms ...` runs `step-kms-plugin ...`) with stdin,
+// stdout, stderr and the exit code forwarded transparently.
+package plugin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smallstep/cli/config"
+)
+
+// Prefix and suffix used to build the name of a plugin executable, e.g.
+// `step-kms-plugin`.
+const (
+	Prefix = "step-"
+	Suffix = "-plugin"
+)
+
+// InfoFlag is the flag a plugin must support to report its metadata as JSON.
+const InfoFlag = "--step-plugin-info"
+
+// defaultTimeout is used to query a plugin for its info when
+// STEP_PLUGIN_TIMEOUT is not set.
+const defaultTimeout = 10 * time.Second
+
+// Info is the JSON metadata a plugin reports in response to InfoFlag.
+type Info struct {
+	Name        string `json:"name"`
+	Usage       string `json:"usage"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// executableName returns the expected executable name for the plugin with
+// the given name, e.g. "kms" -> "step-kms-plugin".
+func executableName(name string) string {
+	filename := Prefix + name + Suffix
+	if runtime.GOOS == "windows" {
+		filename += ".exe"
+	}
+	return filename
+}
+
+// pluginsDir returns $STEPPATH/plugins.
+func pluginsDir() string {
+	return filepath.Join(config.StepPath(), "plugins")
+}
+
+// LookPath searches for the executable of the plugin with the given name,
+// first in $STEPPATH/plugins and then on $PATH, and returns its absolute
+// path.
+func LookPath(name string) (string, error) {
+	filename := executableName(name)
+
+	if path := filepath.Join(pluginsDir(), filename); isExecutable(path) {
+		return path, nil
+	}
+
+	path, err := exec.LookPath(filename)
+	if err != nil {
+		return "", fmt.Errorf("plugin %s not found in $STEPPATH/plugins or $PATH", filename)
+	}
+	return path, nil
+}
+
+func isExecutable(path string) bool {
+	fi, err := os.Stat(path)
+	if err != nil || fi.IsDir() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	return fi.Mode()&0111 != 0
+}
+
+// timeout returns the duration to wait on a plugin before giving up, using
+// STEP_PLUGIN_TIMEOUT (in seconds) if set.
+func timeout() time.Duration {
+	if s := os.Getenv("STEP_PLUGIN_TIMEOUT"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			return time.Duration(n) * time.Second
+		}
+	}
+	return defaultTimeout
+}
+
+// debug reports whether STEPDEBUG is enabled.
+func debug() bool {
+	return os.Getenv("STEPDEBUG") == "1"
+}
+
+// List returns the name of every plugin found in $STEPPATH/plugins and on
+// $PATH, sorted and deduplicated.
+func List() []string {
+	seen := make(map[string]bool)
+	var names []string
+
+	addDir := func(dir string) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if !strings.HasPrefix(name, Prefix) || !strings.HasSuffix(strings.TrimSuffix(name, ".exe"), Suffix) {
+				continue
+			}
+			base := strings.TrimSuffix(name, ".exe")
+			base = strings.TrimPrefix(base, Prefix)
+			base = strings.TrimSuffix(base, Suffix)
+			if base == "" || seen[base] {
+				continue
+			}
+			if !isExecutable(filepath.Join(dir, e.Name())) {
+				continue
+			}
+			seen[base] = true
+			names = append(names, base)
+		}
+	}
+
+	addDir(pluginsDir())
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir != "" {
+			addDir(dir)
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// GetInfo runs the plugin at path with InfoFlag and returns its metadata,
+// using a $STEPPATH/plugins/.cache.json cache keyed by the plugin's mtime so
+// that repeated calls (e.g. building `step help`) do not re-exec every
+// plugin on every invocation.
+func GetInfo(name, path string) (*Info, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	mtime := fi.ModTime().UnixNano()
+
+	c := readCache()
+	if e, ok := c[path]; ok && e.ModTime == mtime {
+		return &e.Info, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, InfoFlag)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if debug() {
+			return nil, fmt.Errorf("failed to get info from plugin %s: %w: %s", name, err, stderr.String())
+		}
+		return nil, fmt.Errorf("failed to get info from plugin %s: %w", name, err)
+	}
+
+	var info Info
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &info); err != nil {
+		return nil, fmt.Errorf("failed to parse info from plugin %s: %w", name, err)
+	}
+	if info.Name == "" {
+		info.Name = name
+	}
+
+	c[path] = cacheEntry{ModTime: mtime, Info: info}
+	writeCache(c)
+
+	return &info, nil
+}
+
+type cacheEntry struct {
+	ModTime int64 `json:"modTime"`
+	Info    Info  `json:"info"`
+}
+
+func cacheFile() string {
+	return filepath.Join(pluginsDir(), ".cache.json")
+}
+
+func readCache() map[string]cacheEntry {
+	c := make(map[string]cacheEntry)
+	b, err := os.ReadFile(cacheFile())
+	if err != nil {
+		return c
+	}
+	_ = json.Unmarshal(b, &c)
+	return c
+}
+
+func writeCache(c map[string]cacheEntry) {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return
+	}
+	_ = os.MkdirAll(pluginsDir(), 0700)
+	_ = os.WriteFile(cacheFile(), b, 0600)
+}
+
+// Run executes the plugin at path with the given arguments, forwarding
+// stdin, stdout and stderr, and returns the plugin's exit code.
+func Run(path string, args []string) (int, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return exitErr.ExitCode(), nil
+		}
+		return 1, fmt.Errorf("failed to run plugin %s: %w", filepath.Base(path), err)
+	}
+	return 0, nil
+}
@@ -0,0 +1,93 @@
+package plugin
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+// writeFakePlugin creates an executable file named step-<name>-plugin in
+// dir, so tests can exercise List and LookPath without any real plugin
+// binaries installed.
+func writeFakePlugin(t *testing.T, dir, name string) {
+	t.Helper()
+	filename := Prefix + name + Suffix
+	if runtime.GOOS == "windows" {
+		filename += ".exe"
+	}
+	path := filepath.Join(dir, filename)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("error writing fake plugin: %v", err)
+	}
+}
+
+func TestLookPath(t *testing.T) {
+	dir := t.TempDir()
+	writeFakePlugin(t, dir, "kms")
+	t.Setenv("PATH", dir)
+	t.Setenv("STEPPATH", t.TempDir())
+
+	path, err := LookPath("kms")
+	if err != nil {
+		t.Fatalf("LookPath() error: %v", err)
+	}
+	if filepath.Dir(path) != dir {
+		t.Fatalf("LookPath() = %q, want a path under %q", path, dir)
+	}
+
+	if _, err := LookPath("does-not-exist"); err == nil {
+		t.Fatal("LookPath() succeeded for a plugin that doesn't exist")
+	}
+}
+
+func TestLookPath_PrefersSTEPPATHPlugins(t *testing.T) {
+	pathDir := t.TempDir()
+	stepPath := t.TempDir()
+	pluginsDir := filepath.Join(stepPath, "plugins")
+	if err := os.MkdirAll(pluginsDir, 0755); err != nil {
+		t.Fatalf("error creating plugins dir: %v", err)
+	}
+
+	writeFakePlugin(t, pathDir, "kms")
+	writeFakePlugin(t, pluginsDir, "kms")
+
+	t.Setenv("PATH", pathDir)
+	t.Setenv("STEPPATH", stepPath)
+
+	path, err := LookPath("kms")
+	if err != nil {
+		t.Fatalf("LookPath() error: %v", err)
+	}
+	if filepath.Dir(path) != pluginsDir {
+		t.Fatalf("LookPath() = %q, want the $STEPPATH/plugins copy to win", path)
+	}
+}
+
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	writeFakePlugin(t, dir, "kms")
+	writeFakePlugin(t, dir, "ssh-agent")
+	// A non-executable file matching the naming convention must be skipped.
+	if err := os.WriteFile(filepath.Join(dir, Prefix+"disabled"+Suffix), []byte("not executable"), 0644); err != nil {
+		t.Fatalf("error writing disabled plugin: %v", err)
+	}
+	// A file that doesn't match the step-<name>-plugin pattern must be skipped.
+	writeFakePlugin(t, dir, "") // step--plugin, deliberately malformed
+
+	t.Setenv("PATH", dir)
+	t.Setenv("STEPPATH", t.TempDir())
+
+	got := List()
+	sort.Strings(got)
+	want := []string{"kms", "ssh-agent"}
+	if len(got) != len(want) {
+		t.Fatalf("List() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("List() = %v, want %v", got, want)
+		}
+	}
+}